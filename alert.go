@@ -0,0 +1,89 @@
+package log4g
+
+import (
+	"log"
+	"sync"
+	"sync/atomic"
+)
+
+// alertQueueSize bounds how many pending AlertEntry values an
+// alertDispatcher will buffer before it starts dropping them.
+const alertQueueSize = 1024
+
+type (
+	// AlertHook receives every AlertEntry an alertDispatcher lets through.
+	// NewWebhookAlert builds one that posts to an incoming-webhook endpoint;
+	// callers may also supply their own.
+	AlertHook func(entry AlertEntry) error
+
+	// AlertEntry carries the data behind a single Alert/Error call.
+	AlertEntry struct {
+		Timestamp string     `json:"timestamp"`
+		Caller    string     `json:"caller,omitempty"`
+		Message   string     `json:"message"`
+		Fields    []LogField `json:"fields,omitempty"`
+	}
+
+	// alertDispatcher fires hook on a single background goroutine so a slow
+	// or unreachable endpoint can never block the calling Error/Alert site.
+	// Entries are throttled by limiter and, once the queue is full, dropped
+	// with a counted warning rather than blocking the dispatcher further.
+	alertDispatcher struct {
+		hook      AlertHook
+		limiter   *LessLogger
+		queue     chan AlertEntry
+		done      chan struct{}
+		stopped   chan struct{}
+		closeOnce sync.Once
+		dropped   uint64
+	}
+)
+
+func newAlertDispatcher(hook AlertHook, coolDownMillis int) *alertDispatcher {
+	d := &alertDispatcher{
+		hook:    hook,
+		limiter: NewLessLogger(coolDownMillis),
+		queue:   make(chan AlertEntry, alertQueueSize),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go d.loop()
+
+	return d
+}
+
+func (d *alertDispatcher) dispatch(entry AlertEntry) {
+	if !d.limiter.allow(entry.Message) {
+		return
+	}
+
+	select {
+	case d.queue <- entry:
+	default:
+		dropped := atomic.AddUint64(&d.dropped, 1)
+		log.Printf("log4g: alert queue full, dropped %d alert(s) so far", dropped)
+	}
+}
+
+func (d *alertDispatcher) loop() {
+	defer close(d.stopped)
+
+	for {
+		select {
+		case entry := <-d.queue:
+			if err := d.hook(entry); err != nil {
+				log.Printf("log4g: alert hook failed: %v", err)
+			}
+		case <-d.done:
+			return
+		}
+	}
+}
+
+// close stops loop, discarding any alerts still sitting in the queue. It is
+// safe to call more than once, matching Instance.Close being callable that way.
+func (d *alertDispatcher) close() {
+	d.closeOnce.Do(func() {
+		close(d.done)
+	})
+}
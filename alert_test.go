@@ -0,0 +1,43 @@
+package log4g
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestAlertDispatcherCloseStopsLoop guards against Instance.Close leaving an
+// alertDispatcher's loop goroutine running forever.
+func TestAlertDispatcherCloseStopsLoop(t *testing.T) {
+	d := newAlertDispatcher(func(AlertEntry) error { return nil }, 0)
+
+	d.close()
+
+	select {
+	case <-d.stopped:
+	case <-time.After(time.Second):
+		t.Fatal("loop did not stop after close")
+	}
+
+	// Closing again must not panic.
+	d.close()
+}
+
+func TestInstanceCloseStopsAlertDispatcher(t *testing.T) {
+	inst := new(Instance)
+	inst.setUpAlert()
+	inst.options.alertHook = func(AlertEntry) error { return nil }
+	inst.alert = newAlertDispatcher(inst.options.alertHook, 0)
+	atomic.StoreUint32(&inst.initialized, 1)
+	inst.writeConsole = true
+
+	if err := inst.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	select {
+	case <-inst.alert.stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Instance.Close did not stop the alert dispatcher")
+	}
+}
@@ -0,0 +1,98 @@
+package log4g
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	backtraceMu sync.RWMutex
+	backtraceAt map[string]map[int]struct{}
+)
+
+// SetBacktraceAt parses a comma-separated "file.go:123,other.go:45" spec
+// and arranges for output/outputError to append a full stack trace to any
+// log entry whose call site resolves to one of those file:line pairs. This
+// is glog's -log_backtrace_at, useful for finding which call path produces
+// a mysterious log line without redeploying with extra logging.
+func SetBacktraceAt(spec string) error {
+	at, err := parseBacktraceAt(spec)
+	if err != nil {
+		return err
+	}
+
+	backtraceMu.Lock()
+	backtraceAt = at
+	backtraceMu.Unlock()
+
+	return nil
+}
+
+// WithBacktraceAt is the Config-driven equivalent of SetBacktraceAt,
+// applied by setupWithFiles/setupWithConsole alongside the other LogOptions.
+// Like SetBacktraceAt, it mutates process-wide state: it is not scoped to
+// the Instance being configured, so the last Instance to apply it wins
+// across every Instance in the process.
+func WithBacktraceAt(spec string) LogOption {
+	return func(opts *logOptions) {
+		opts.backtraceAt = spec
+	}
+}
+
+func parseBacktraceAt(spec string) (map[string]map[int]struct{}, error) {
+	at := make(map[string]map[int]struct{})
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if len(part) == 0 {
+			continue
+		}
+
+		idx := strings.LastIndex(part, ":")
+		if idx < 0 {
+			return nil, fmt.Errorf("log4g: invalid backtrace-at entry %q", part)
+		}
+
+		file, lineStr := part[:idx], part[idx+1:]
+		line, err := strconv.Atoi(lineStr)
+		if err != nil {
+			return nil, fmt.Errorf("log4g: invalid backtrace-at line in %q: %w", part, err)
+		}
+
+		if at[file] == nil {
+			at[file] = make(map[int]struct{})
+		}
+		at[file][line] = struct{}{}
+	}
+
+	return at, nil
+}
+
+// backtraceConfigured reports whether the current SetBacktraceAt spec names
+// any file:line, letting callers skip resolving their own caller (a
+// runtime.Caller walk) on the overwhelmingly common path where it hasn't.
+func backtraceConfigured() bool {
+	backtraceMu.RLock()
+	defer backtraceMu.RUnlock()
+
+	return len(backtraceAt) > 0
+}
+
+// backtraceMatches reports whether file:line, the source location
+// runtime.Caller resolved for a log call site, was named in SetBacktraceAt.
+// Like glog, it matches on the file's base name rather than the full path.
+func backtraceMatches(file string, line int) bool {
+	backtraceMu.RLock()
+	defer backtraceMu.RUnlock()
+
+	lines, ok := backtraceAt[path.Base(file)]
+	if !ok {
+		return false
+	}
+
+	_, ok = lines[line]
+
+	return ok
+}
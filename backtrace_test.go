@@ -0,0 +1,83 @@
+package log4g
+
+import (
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// TestBacktraceAtFires guards against the backtrace-at depth compensation
+// pointing at the wrong frame, which made a configured file:line never
+// match the real call site and so never append a stack trace.
+func TestBacktraceAtFires(t *testing.T) {
+	defer func() {
+		_ = SetBacktraceAt("")
+	}()
+
+	errBuf := new(bufSink)
+	defaultInstance.errorLog = errBuf
+	atomic.StoreUint32(&defaultInstance.initialized, 1)
+
+	for _, encoding := range []string{jsonEncoding, ""} {
+		defaultInstance.options.encoding = encoding
+		errBuf.Reset()
+
+		_, file, line, _ := runtime.Caller(0)
+		setBacktraceAtOrFail(t, file, line+2)
+		Error("traced")
+
+		if !strings.Contains(errBuf.String(), "goroutine") {
+			t.Fatalf("encoding %q: expected a stack trace to be appended, got %s", encoding, errBuf.String())
+		}
+	}
+}
+
+func setBacktraceAtOrFail(t *testing.T, file string, line int) {
+	t.Helper()
+	if err := SetBacktraceAt(callerOf(file, line)); err != nil {
+		t.Fatalf("SetBacktraceAt: %v", err)
+	}
+}
+
+func TestParseBacktraceAt(t *testing.T) {
+	at, err := parseBacktraceAt("main.go:10,other.go:20,main.go:30")
+	if err != nil {
+		t.Fatalf("parseBacktraceAt: %v", err)
+	}
+
+	if len(at["main.go"]) != 2 {
+		t.Fatalf("expected 2 lines for main.go, got %v", at["main.go"])
+	}
+	if len(at["other.go"]) != 1 {
+		t.Fatalf("expected 1 line for other.go, got %v", at["other.go"])
+	}
+}
+
+func TestParseBacktraceAtInvalid(t *testing.T) {
+	for _, spec := range []string{"main.go", "main.go:notanumber"} {
+		if _, err := parseBacktraceAt(spec); err == nil {
+			t.Errorf("parseBacktraceAt(%q): expected an error, got nil", spec)
+		}
+	}
+}
+
+func TestBacktraceMatchesBaseName(t *testing.T) {
+	defer func() {
+		_ = SetBacktraceAt("")
+	}()
+
+	if err := SetBacktraceAt("main.go:10"); err != nil {
+		t.Fatalf("SetBacktraceAt: %v", err)
+	}
+
+	if !backtraceMatches("/src/app/main.go", 10) {
+		t.Error("expected a full-path file matching by base name to match")
+	}
+	if backtraceMatches("/src/app/main.go", 11) {
+		t.Error("expected a non-matching line to not match")
+	}
+	if backtraceMatches("/src/app/other.go", 10) {
+		t.Error("expected a non-matching file to not match")
+	}
+}
@@ -0,0 +1,45 @@
+package log4g
+
+// Config is the configuration for setting up the logger via Init/SetUp.
+type Config struct {
+	// LogMode controls where log entries go: "console", "volume" or the
+	// default file mode. See SetUp for how each mode is handled.
+	LogMode string `json:",default=console"`
+	// Path is the base directory used to write log files in file/volume mode.
+	Path string `json:",optional"`
+	// NameSpace is appended under Path (together with the hostname) in volume mode.
+	NameSpace string `json:",optional"`
+	// Compress gzips rotated backup files when set.
+	Compress bool `json:",optional"`
+	// KeepDays is how many days of backup files DefaultBackupRule keeps around.
+	KeepDays int `json:",optional"`
+	// StackCoolDownMillis throttles how often Server/ServerFormat may write a stack trace.
+	StackCoolDownMillis int `json:",optional,default=1000"`
+	// Encoding selects the output format: "plain" (default) or "json".
+	Encoding string `json:",optional,options=plain|json,default=plain"`
+	// VModule is a glog-style per-file verbosity spec, e.g. "cache=2,handler/*=3".
+	// It's applied process-wide via SetVModule, not scoped to the Instance
+	// being configured: the last call to New/SetUp/WithVModule wins across
+	// every Instance in the process.
+	VModule string `json:",optional"`
+	// BacktraceAt is a glog-style "file.go:123,other.go:45" spec; a log
+	// entry whose call site matches one of those file:line pairs gets a
+	// full stack trace appended, regardless of level. Like VModule, it's
+	// applied process-wide via SetBacktraceAt regardless of which Instance's
+	// Config names it.
+	BacktraceAt string `json:",optional"`
+	// RotationRule selects how backup files roll over: "daily" (the
+	// default), "hourly", or "size".
+	RotationRule string `json:",optional,options=daily|hourly|size,default=daily"`
+	// MaxSize is the per-file size limit in megabytes, only used when
+	// RotationRule is "size".
+	MaxSize int `json:",optional"`
+	// MaxLines is the per-file line limit, only used when RotationRule is "size".
+	MaxLines int `json:",optional"`
+	// MaxBackups is how many rotated files to keep around, only used when
+	// RotationRule is "size"; the daily/hourly rules prune by KeepDays instead.
+	MaxBackups int `json:",optional"`
+	// Sinks are additional non-file destinations (syslog, TCP, ...) that every
+	// log stream is fanned out to alongside the regular file/console output.
+	Sinks []SinkConfig `json:",optional"`
+}
@@ -0,0 +1,477 @@
+package log4g
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"runtime"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+)
+
+// Instance is a self-contained logger with its own writers, options and
+// LessLogger, independent of any other Instance in the same process. The
+// package-level Info/Error/Slow/Stat/Server functions operate on a single
+// lazily-configured default Instance; build additional ones with New to run
+// several independently-configured loggers (e.g. access log vs audit log vs
+// billing log) side by side.
+//
+// Config.VModule and Config.BacktraceAt are the exception: SetVModule and
+// SetBacktraceAt apply process-wide regardless of which Instance's Config
+// names them, so the last Instance configured with either one wins across
+// the whole process, not just for that Instance.
+type Instance struct {
+	writeConsole bool
+	initialized  uint32
+	options      logOptions
+	infoLog      Sink
+	errorLog     Sink
+	slowLog      Sink
+	statLog      Sink
+	stackLog     *LessLogger
+	alert        *alertDispatcher
+}
+
+// New builds and configures a standalone Instance according to c and opts.
+func New(c Config, opts ...LogOption) (*Instance, error) {
+	inst := new(Instance)
+	if err := inst.setUp(c, opts...); err != nil {
+		return nil, err
+	}
+
+	return inst, nil
+}
+
+func (inst *Instance) setUp(c Config, extraOpts ...LogOption) error {
+	switch c.LogMode {
+	case consoleMode:
+		return inst.setupWithConsole(c, extraOpts...)
+	case varMode:
+		return inst.setupWithVolume(c, extraOpts...)
+	default:
+		return inst.setupWithFiles(c, extraOpts...)
+	}
+}
+
+func (inst *Instance) setupWithConsole(c Config, extraOpts ...LogOption) error {
+	inst.writeConsole = true
+
+	var opts []LogOption
+	if len(c.Encoding) > 0 {
+		opts = append(opts, WithEncoding(c.Encoding))
+	}
+	if len(c.VModule) > 0 {
+		opts = append(opts, WithVModule(c.VModule))
+	}
+	if len(c.BacktraceAt) > 0 {
+		opts = append(opts, WithBacktraceAt(c.BacktraceAt))
+	}
+	opts = append(opts, extraOpts...)
+
+	externalSinks, err := buildSinks(c.Sinks)
+	if err != nil {
+		return err
+	}
+
+	inst.handleOptions(opts)
+	if len(inst.options.vmodule) > 0 {
+		_ = SetVModule(inst.options.vmodule)
+	}
+	if len(inst.options.backtraceAt) > 0 {
+		_ = SetBacktraceAt(inst.options.backtraceAt)
+	}
+	inst.setUpAlert()
+
+	inst.infoLog = inst.consoleSink(os.Stdout, infoPrefix, externalSinks)
+	inst.errorLog = inst.consoleSink(os.Stderr, errorPrefix, externalSinks)
+	inst.slowLog = inst.consoleSink(os.Stderr, slowPrefix, externalSinks)
+	inst.statLog = inst.infoLog
+	atomic.StoreUint32(&inst.initialized, 1)
+
+	return nil
+}
+
+func (inst *Instance) consoleSink(w *os.File, prefix string, externalSinks []Sink) Sink {
+	sink := newIOSink(newLogWriter(log.New(w, prefix, flags)))
+	if len(externalSinks) == 0 {
+		return sink
+	}
+
+	return NewMultiSink(append([]Sink{sink}, externalSinks...)...)
+}
+
+func (inst *Instance) setupWithFiles(c Config, extraOpts ...LogOption) error {
+	var opts []LogOption
+
+	if len(c.Path) == 0 {
+		return ErrLogPathNotSet
+	}
+
+	opts = append(opts, WithCoolDownMillis(c.StackCoolDownMillis))
+	if c.Compress {
+		opts = append(opts, WithGzip())
+	}
+	if c.KeepDays > 0 {
+		opts = append(opts, WithKeepDays(c.KeepDays))
+	}
+	if len(c.Encoding) > 0 {
+		opts = append(opts, WithEncoding(c.Encoding))
+	}
+	if len(c.VModule) > 0 {
+		opts = append(opts, WithVModule(c.VModule))
+	}
+	if len(c.RotationRule) > 0 {
+		opts = append(opts, WithRotationRule(c.RotationRule))
+	}
+	if len(c.BacktraceAt) > 0 {
+		opts = append(opts, WithBacktraceAt(c.BacktraceAt))
+	}
+	if c.MaxSize > 0 {
+		opts = append(opts, WithMaxSize(c.MaxSize))
+	}
+	if c.MaxLines > 0 {
+		opts = append(opts, WithMaxLines(c.MaxLines))
+	}
+	if c.MaxBackups > 0 {
+		opts = append(opts, WithMaxBackups(c.MaxBackups))
+	}
+	opts = append(opts, extraOpts...)
+
+	accessFile := path.Join(c.Path, accessFilename)
+	errorFile := path.Join(c.Path, errorFilename)
+	slowFile := path.Join(c.Path, slowFilename)
+	statFile := path.Join(c.Path, statFilename)
+
+	externalSinks, err := buildSinks(c.Sinks)
+	if err != nil {
+		return err
+	}
+
+	inst.handleOptions(opts)
+	if len(inst.options.vmodule) > 0 {
+		if err := SetVModule(inst.options.vmodule); err != nil {
+			return err
+		}
+	}
+	if len(inst.options.backtraceAt) > 0 {
+		if err := SetBacktraceAt(inst.options.backtraceAt); err != nil {
+			return err
+		}
+	}
+	inst.setUpAlert()
+
+	if inst.infoLog, err = inst.createOutput(accessFile, externalSinks); err != nil {
+		return err
+	}
+	if inst.errorLog, err = inst.createOutput(errorFile, externalSinks); err != nil {
+		return err
+	}
+	if inst.slowLog, err = inst.createOutput(slowFile, externalSinks); err != nil {
+		return err
+	}
+	if inst.statLog, err = inst.createOutput(statFile, externalSinks); err != nil {
+		return err
+	}
+
+	inst.stackLog = NewLessLogger(inst.options.logStackCoolDownMills)
+	atomic.StoreUint32(&inst.initialized, 1)
+
+	return nil
+}
+
+func (inst *Instance) setupWithVolume(c Config, extraOpts ...LogOption) error {
+	if len(c.NameSpace) == 0 {
+		return ErrLogNameSpaceNotSet
+	}
+
+	hostname := getHostname()
+	c.Path = path.Join(c.Path, c.NameSpace, hostname)
+
+	return inst.setupWithFiles(c, extraOpts...)
+}
+
+func (inst *Instance) createOutput(accessPath string, externalSinks []Sink) (Sink, error) {
+	if len(accessPath) == 0 {
+		return nil, ErrLogPathNotSet
+	}
+
+	fileWriter, err := NewLogger(accessPath, inst.backupRule(accessPath), inst.options.gzipEnabled)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(externalSinks) == 0 {
+		return newIOSink(fileWriter), nil
+	}
+
+	return NewMultiSink(append([]Sink{newIOSink(fileWriter)}, externalSinks...)...), nil
+}
+
+func (inst *Instance) backupRule(accessPath string) BackupRule {
+	switch inst.options.rotationRule {
+	case rotationRuleSize:
+		return NewSizeBackupRule(accessPath, backupFileDelimiter, inst.options.maxSize, inst.options.maxLines,
+			inst.options.maxBackups, inst.options.gzipEnabled)
+	case rotationRuleHourly:
+		return NewHourlyBackupRule(accessPath, backupFileDelimiter, inst.options.keepDays, inst.options.gzipEnabled)
+	default:
+		return DefaultBackupRule(accessPath, backupFileDelimiter, inst.options.keepDays, inst.options.gzipEnabled)
+	}
+}
+
+func (inst *Instance) handleOptions(opts []LogOption) {
+	for _, opt := range opts {
+		opt(&inst.options)
+	}
+}
+
+// Close flushes and closes every writer owned by inst, and stops its alert
+// dispatcher's background goroutine, if any. In console mode the console
+// writers themselves are no-ops to close, but any external Config.Sinks
+// fanned in via consoleSink (e.g. a TCPSink/SyslogSink) still own a live
+// connection that must be closed here.
+func (inst *Instance) Close() error {
+	if inst.alert != nil {
+		inst.alert.close()
+	}
+
+	if atomic.LoadUint32(&inst.initialized) == 0 {
+		return ErrLogNotInitialized
+	}
+
+	atomic.StoreUint32(&inst.initialized, 0)
+
+	if inst.infoLog != nil {
+		if err := inst.infoLog.Close(); err != nil {
+			return err
+		}
+	}
+	if inst.errorLog != nil {
+		if err := inst.errorLog.Close(); err != nil {
+			return err
+		}
+	}
+	if inst.slowLog != nil {
+		if err := inst.slowLog.Close(); err != nil {
+			return err
+		}
+	}
+	if inst.statLog != nil {
+		if err := inst.statLog.Close(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (inst *Instance) Error(v ...interface{}) {
+	inst.ErrorCaller(1, v...)
+}
+
+func (inst *Instance) ErrorFormat(format string, v ...interface{}) {
+	inst.ErrorCallerFormat(1, format, v...)
+}
+
+func (inst *Instance) ErrorCaller(callDepth int, v ...interface{}) {
+	inst.errorSync(fmt.Sprintln(v...), callDepth+callerInnerDepth)
+}
+
+func (inst *Instance) ErrorCallerFormat(callDepth int, format string, v ...interface{}) {
+	inst.errorSync(fmt.Sprintf(fmt.Sprintf("%s\n", format), v...), callDepth+callerInnerDepth)
+}
+
+// ErrorW logs msg at error level, merging fields into the entry when JSON
+// encoding is enabled. Fields are dropped in plain text mode.
+func (inst *Instance) ErrorW(msg string, fields ...LogField) {
+	inst.errorSync(msg, callerInnerDepth+1, fields...)
+}
+
+// Alert fires the hook registered via WithAlertHook, if any, without also
+// writing an error log entry. Error/ErrorCaller/ErrorW fire the same hook.
+func (inst *Instance) Alert(msg string) {
+	// fireAlert is called here without errorSync in between, so the
+	// package-level Alert's call site sits at callerInnerDepth-1.
+	inst.fireAlert(msg, callerInnerDepth-1, nil)
+}
+
+func (inst *Instance) Info(v ...interface{}) {
+	inst.infoSync(fmt.Sprintln(v...))
+}
+
+func (inst *Instance) InfoFormat(format string, v ...interface{}) {
+	inst.infoSync(fmt.Sprintf(fmt.Sprintf("%s\n", format), v...))
+}
+
+// InfoW logs msg at info level, merging fields into the entry when JSON
+// encoding is enabled. Fields are dropped in plain text mode.
+func (inst *Instance) InfoW(msg string, fields ...LogField) {
+	inst.infoSync(msg, fields...)
+}
+
+func (inst *Instance) Server(v ...interface{}) {
+	inst.stackSync(fmt.Sprint(v...))
+}
+
+func (inst *Instance) ServerFormat(format string, v ...interface{}) {
+	inst.stackSync(fmt.Sprintf(format, v...))
+}
+
+func (inst *Instance) Slow(v ...interface{}) {
+	inst.slowSync(fmt.Sprintln(v...))
+}
+
+func (inst *Instance) SlowFormat(format string, v ...interface{}) {
+	inst.slowSync(fmt.Sprintf(fmt.Sprintf("%s\n", format), v...))
+}
+
+// SlowW logs msg at slow level, merging fields into the entry when JSON
+// encoding is enabled. Fields are dropped in plain text mode.
+func (inst *Instance) SlowW(msg string, fields ...LogField) {
+	inst.slowSync(msg, fields...)
+}
+
+func (inst *Instance) Stat(v ...interface{}) {
+	inst.statSync(fmt.Sprintln(v...))
+}
+
+func (inst *Instance) StatFormat(format string, v ...interface{}) {
+	inst.statSync(fmt.Sprintf(fmt.Sprintf("%s\n", format), v...))
+}
+
+// StatW logs msg at stat level, merging fields into the entry when JSON
+// encoding is enabled. Fields are dropped in plain text mode.
+func (inst *Instance) StatW(msg string, fields ...LogField) {
+	inst.statSync(msg, fields...)
+}
+
+func (inst *Instance) errorSync(msg string, callDepth int, fields ...LogField) {
+	if atomic.LoadUint32(&inst.initialized) == 0 {
+		inst.outputError(nil, msg, callDepth, fields)
+	} else {
+		inst.outputError(inst.errorLog, msg, callDepth, fields)
+	}
+
+	// fireAlert resolves its caller directly, like outputError's JSON branch,
+	// so it needs the same callDepth-1.
+	inst.fireAlert(msg, callDepth-1, fields)
+}
+
+func (inst *Instance) setUpAlert() {
+	if inst.options.alertHook == nil {
+		return
+	}
+
+	inst.alert = newAlertDispatcher(inst.options.alertHook, inst.options.logStackCoolDownMills)
+}
+
+func (inst *Instance) fireAlert(msg string, callDepth int, fields []LogField) {
+	if inst.alert == nil {
+		return
+	}
+
+	inst.alert.dispatch(AlertEntry{
+		Timestamp: time.Now().Format(TimeFormat),
+		Caller:    getCaller(callDepth),
+		Message:   msg,
+		Fields:    fields,
+	})
+}
+
+func (inst *Instance) infoSync(msg string, fields ...LogField) {
+	// infoSync adds one more stack frame than a direct infoSyncDepth call
+	// (slowSync/statSync call output at jsonCallerDepth directly), so it
+	// needs the depth bumped by one to still land on the real call site.
+	inst.infoSyncDepth(jsonCallerDepth+1, msg, fields...)
+}
+
+func (inst *Instance) infoSyncDepth(callDepth int, msg string, fields ...LogField) {
+	if atomic.LoadUint32(&inst.initialized) == 0 {
+		inst.output(nil, levelInfo, callDepth, msg, fields)
+	} else {
+		inst.output(inst.infoLog, levelInfo, callDepth, msg, fields)
+	}
+}
+
+func (inst *Instance) slowSync(msg string, fields ...LogField) {
+	if atomic.LoadUint32(&inst.initialized) == 0 {
+		inst.output(nil, levelSlow, jsonCallerDepth, msg, fields)
+	} else {
+		inst.output(inst.slowLog, levelSlow, jsonCallerDepth, msg, fields)
+	}
+}
+
+func (inst *Instance) statSync(msg string, fields ...LogField) {
+	if atomic.LoadUint32(&inst.initialized) == 0 {
+		inst.output(nil, levelStat, jsonCallerDepth, msg, fields)
+	} else {
+		inst.output(inst.statLog, levelStat, jsonCallerDepth, msg, fields)
+	}
+}
+
+func (inst *Instance) stackSync(msg string) {
+	if atomic.LoadUint32(&inst.initialized) == 0 {
+		inst.output(nil, levelInfo, jsonCallerDepth, fmt.Sprintf("%s\n%s", msg, string(debug.Stack())), nil)
+	} else {
+		inst.stackLog.Errorf("%s\n%s", msg, string(debug.Stack()))
+	}
+}
+
+// appendBacktraceIfMatched resolves the caller at callDepth and, if it was
+// named via SetBacktraceAt/WithBacktraceAt, appends a full stack trace to
+// content.
+func appendBacktraceIfMatched(content string, callDepth int) string {
+	if !backtraceConfigured() {
+		return content
+	}
+
+	_, file, line, ok := runtime.Caller(callDepth)
+	if !ok || !backtraceMatches(file, line) {
+		return content
+	}
+
+	return fmt.Sprintf("%s\n%s", content, debug.Stack())
+}
+
+func (inst *Instance) output(writer Sink, level string, callDepth int, msg string, fields []LogField) {
+	var content string
+	if inst.options.encoding == jsonEncoding {
+		content = encodeEntry(level, getCaller(callDepth), msg, fields)
+	} else {
+		content = AddTime(msg)
+	}
+	content = appendBacktraceIfMatched(content, callDepth)
+
+	if writer != nil {
+		if err := writer.Write(levelCode(level), []byte(content)); err != nil {
+			log.Println(err)
+		}
+	} else {
+		log.Print(content)
+	}
+}
+
+func (inst *Instance) outputError(writer Sink, msg string, callDepth int, fields []LogField) {
+	var content string
+	// appendBacktraceIfMatched, like the JSON branch's getCaller call below,
+	// resolves its caller directly rather than through AddTimeAndCaller's
+	// extra indirection, so it needs the same callDepth-1 regardless of
+	// which branch produced content below.
+	backtraceDepth := callDepth - 1
+	if inst.options.encoding == jsonEncoding {
+		content = encodeEntry(levelError, getCaller(callDepth-1), msg, fields)
+	} else {
+		content = AddTimeAndCaller(msg, callDepth)
+	}
+	content = appendBacktraceIfMatched(content, backtraceDepth)
+
+	if writer != nil {
+		if err := writer.Write(LevelError, []byte(content)); err != nil {
+			log.Println(err)
+		}
+	} else {
+		log.Print(content)
+	}
+}
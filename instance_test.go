@@ -0,0 +1,73 @@
+package log4g
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// bufSink is a Sink that collects writes in memory, for asserting on the
+// exact bytes a logger produced without touching stdout/stderr or the disk.
+type bufSink struct {
+	strings.Builder
+}
+
+func (s *bufSink) Write(_ int, entry []byte) error {
+	_, err := s.Builder.Write(entry)
+	return err
+}
+
+func (s *bufSink) Close() error {
+	return nil
+}
+
+func callerOf(file string, line int) string {
+	return fmt.Sprintf("%s:%d", filepath.Base(file), line)
+}
+
+// TestJSONCallerDepth guards against the depth math that resolves the
+// "caller" field drifting back to a wrapper frame (log4g.go/instance.go)
+// instead of the real call site, for every JSON entrypoint family. It drives
+// the package-level Info/Error/InfoW/ErrorW functions directly, since those
+// add a stack frame (over calling the Instance methods directly) that the
+// depth constants must account for.
+func TestJSONCallerDepth(t *testing.T) {
+	infoBuf := new(bufSink)
+	errBuf := new(bufSink)
+	defaultInstance.options.encoding = jsonEncoding
+	defaultInstance.infoLog = infoBuf
+	defaultInstance.statLog = infoBuf
+	defaultInstance.errorLog = errBuf
+	atomic.StoreUint32(&defaultInstance.initialized, 1)
+
+	_, file, infoLine, _ := runtime.Caller(0)
+	Info("hello")
+	wantInfo := callerOf(file, infoLine+1)
+	if !strings.Contains(infoBuf.String(), `"caller":"`+wantInfo+`"`) {
+		t.Fatalf("Info: expected caller %s, got %s", wantInfo, infoBuf.String())
+	}
+
+	_, file, infoWLine, _ := runtime.Caller(0)
+	InfoW("hello-w")
+	wantInfoW := callerOf(file, infoWLine+1)
+	if !strings.Contains(infoBuf.String(), `"caller":"`+wantInfoW+`"`) {
+		t.Fatalf("InfoW: expected caller %s, got %s", wantInfoW, infoBuf.String())
+	}
+
+	_, file, errLine, _ := runtime.Caller(0)
+	Error("boom")
+	wantErr := callerOf(file, errLine+1)
+	if !strings.Contains(errBuf.String(), `"caller":"`+wantErr+`"`) {
+		t.Fatalf("Error: expected caller %s, got %s", wantErr, errBuf.String())
+	}
+
+	_, file, errWLine, _ := runtime.Caller(0)
+	ErrorW("boom-w")
+	wantErrW := callerOf(file, errWLine+1)
+	if !strings.Contains(errBuf.String(), `"caller":"`+wantErrW+`"`) {
+		t.Fatalf("ErrorW: expected caller %s, got %s", wantErrW, errBuf.String())
+	}
+}
@@ -0,0 +1,66 @@
+package log4g
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// LessLogger throttles repeated identical messages, allowing at most one
+// through per cool-down window. Suppressed repeats are counted per message,
+// but the count is not written out and is reset when the next one gets
+// through. It backs Server/ServerFormat's stack dumps and the alert
+// dispatcher's duplicate-message suppression.
+type LessLogger struct {
+	threshold time.Duration
+	lock      sync.Mutex
+	seen      map[string]*lessLoggerEntry
+}
+
+type lessLoggerEntry struct {
+	last    time.Time
+	dropped int
+}
+
+// NewLessLogger returns a LessLogger that suppresses repeats of the same
+// message for coolDownMillis milliseconds. A non-positive coolDownMillis
+// disables throttling.
+func NewLessLogger(coolDownMillis int) *LessLogger {
+	return &LessLogger{
+		threshold: time.Duration(coolDownMillis) * time.Millisecond,
+		seen:      make(map[string]*lessLoggerEntry),
+	}
+}
+
+// Errorf formats msg and writes it through the standard logger, dropping
+// repeats that land inside the cool-down window.
+func (l *LessLogger) Errorf(format string, v ...interface{}) {
+	msg := fmt.Sprintf(format, v...)
+	if !l.allow(msg) {
+		return
+	}
+
+	log.Print(msg)
+}
+
+// allow reports whether msg may pass, recording it as the most recent
+// occurrence when it does and counting it as dropped otherwise.
+func (l *LessLogger) allow(msg string) bool {
+	if l.threshold <= 0 {
+		return true
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := time.Now()
+	entry, ok := l.seen[msg]
+	if !ok || now.Sub(entry.last) >= l.threshold {
+		l.seen[msg] = &lessLoggerEntry{last: now}
+		return true
+	}
+
+	entry.dropped++
+	return false
+}
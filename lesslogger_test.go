@@ -0,0 +1,33 @@
+package log4g
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLessLoggerAllowThrottles(t *testing.T) {
+	l := NewLessLogger(1000)
+
+	if !l.allow("boom") {
+		t.Fatal("first occurrence should always be allowed")
+	}
+	if l.allow("boom") {
+		t.Fatal("repeat within the cool-down window should be dropped")
+	}
+	if !l.allow("other") {
+		t.Fatal("a different message should be allowed independently")
+	}
+
+	l.seen["boom"].last = time.Now().Add(-2 * time.Second)
+	if !l.allow("boom") {
+		t.Fatal("expected the message to be allowed again once the cool-down window passed")
+	}
+}
+
+func TestLessLoggerAllowDisabled(t *testing.T) {
+	l := NewLessLogger(0)
+
+	if !l.allow("boom") || !l.allow("boom") {
+		t.Fatal("a non-positive cool-down should never throttle")
+	}
+}
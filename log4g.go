@@ -1,18 +1,14 @@
 package log4g
 
 import (
+	"encoding/json"
 	"errors"
-	"fmt"
-	"io"
 	"log"
 	"os"
-	"path"
 	"runtime"
-	"runtime/debug"
 	"strconv"
 	"strings"
 	"sync"
-	"sync/atomic"
 	"time"
 )
 
@@ -35,6 +31,18 @@ const (
 	backupFileDelimiter = "-"
 	callerInnerDepth    = 5
 	flags               = 0x0
+
+	jsonEncoding = "json"
+
+	levelInfo  = "info"
+	levelError = "error"
+	levelSlow  = "slow"
+	levelStat  = "stat"
+
+	// jsonCallerDepth is the runtime.Caller depth for the entrypoints that
+	// never took a callDepth parameter (Info/Slow/Stat) but still need a
+	// caller attached in JSON mode.
+	jsonCallerDepth = callerInnerDepth
 )
 
 var (
@@ -42,16 +50,8 @@ var (
 	ErrLogNotInitialized  = errors.New("log not initialized")
 	ErrLogNameSpaceNotSet = errors.New("log service name must be set")
 
-	writeConsole bool
-	infoLog      io.WriteCloser
-	errorLog     io.WriteCloser
-	slowLog      io.WriteCloser
-	statLog      io.WriteCloser
-	stackLog     *LessLogger
-
-	once        sync.Once
-	initialized uint32
-	options     logOptions
+	once            sync.Once
+	defaultInstance = new(Instance)
 )
 
 type (
@@ -59,6 +59,14 @@ type (
 		gzipEnabled           bool
 		logStackCoolDownMills int
 		keepDays              int
+		encoding              string
+		vmodule               string
+		maxSize               int64
+		maxLines              int64
+		maxBackups            int
+		rotationRule          string
+		alertHook             AlertHook
+		backtraceAt           string
 	}
 
 	LogOption func(options *logOptions)
@@ -69,24 +77,40 @@ type (
 		Info(...interface{})
 		InfoFormat(string, ...interface{})
 	}
+
+	// LogField is a key-value pair carried by the InfoW/ErrorW/SlowW/StatW
+	// entrypoints. Fields are merged into the JSON object when
+	// WithEncoding("json") is in effect and ignored in plain text mode.
+	LogField struct {
+		Key   string
+		Value interface{}
+	}
 )
 
-func Init(c Config) {
-	if err := SetUp(c); err != nil {
+// Init sets up the default, process-wide logger, exiting the process if c
+// is invalid. Use New instead to build an independent, named Instance.
+func Init(c Config, opts ...LogOption) {
+	if err := SetUp(c, opts...); err != nil {
 		log.Fatal(err)
 	}
 }
 
-func SetUp(c Config) error {
-	switch c.LogMode {
-	case consoleMode:
-		setupWithConsole()
-		return nil
-	case varMode:
-		return setupWithVolume(c)
-	default:
-		return setupWithFiles(c)
-	}
+// SetUp configures the default logger used by the package-level Info/Error/
+// Slow/Stat/Server functions. It only takes effect on the first call. opts
+// carries options with no Config equivalent, such as WithAlertHook.
+func SetUp(c Config, opts ...LogOption) error {
+	var err error
+	once.Do(func() {
+		err = defaultInstance.setUp(c, opts...)
+	})
+
+	return err
+}
+
+// Alert fires the hook registered via WithAlertHook on the default logger,
+// if any, without also writing an error log entry.
+func Alert(msg string) {
+	defaultInstance.Alert(msg)
 }
 
 func AddTime(msg string) string {
@@ -118,89 +142,85 @@ func AddTimeAndCaller(msg string, callDepth int) string {
 }
 
 func Close() error {
-	if writeConsole {
-		return nil
-	}
-
-	if atomic.LoadUint32(&initialized) == 0 {
-		return ErrLogNotInitialized
-	}
-
-	atomic.StoreUint32(&initialized, 0)
-
-	if infoLog != nil {
-		if err := infoLog.Close(); err != nil {
-			return err
-		}
-	}
-
-	if errorLog != nil {
-		if err := errorLog.Close(); err != nil {
-			return err
-		}
-	}
-
-	if slowLog != nil {
-		if err := slowLog.Close(); err != nil {
-			return err
-		}
-	}
-
-	if statLog != nil {
-		if err := statLog.Close(); err != nil {
-			return err
-		}
-	}
-
-	return nil
+	return defaultInstance.Close()
 }
 
 func Error(v ...interface{}) {
-	ErrorCaller(1, v...)
+	defaultInstance.ErrorCaller(1, v...)
 }
 
 func ErrorFormat(format string, v ...interface{}) {
-	ErrorCallerFormat(1, format, v...)
+	defaultInstance.ErrorCallerFormat(1, format, v...)
 }
 
 func ErrorCaller(callDepth int, v ...interface{}) {
-	errorSync(fmt.Sprintln(v...), callDepth+callerInnerDepth)
+	defaultInstance.ErrorCaller(callDepth, v...)
 }
 
 func ErrorCallerFormat(callDepth int, format string, v ...interface{}) {
-	errorSync(fmt.Sprintf(fmt.Sprintf("%s\n", format), v...), callDepth+callerInnerDepth)
+	defaultInstance.ErrorCallerFormat(callDepth, format, v...)
+}
+
+// ErrorW logs msg at error level, merging fields into the entry when JSON
+// encoding is enabled. Fields are dropped in plain text mode.
+func ErrorW(msg string, fields ...LogField) {
+	defaultInstance.ErrorW(msg, fields...)
 }
 
 func Info(v ...interface{}) {
-	infoSync(fmt.Sprintln(v...))
+	defaultInstance.Info(v...)
 }
 
 func InfoFormat(format string, v ...interface{}) {
-	infoSync(fmt.Sprintf(fmt.Sprintf("%s\n", format), v...))
+	defaultInstance.InfoFormat(format, v...)
+}
+
+// InfoW logs msg at info level, merging fields into the entry when JSON
+// encoding is enabled. Fields are dropped in plain text mode.
+func InfoW(msg string, fields ...LogField) {
+	defaultInstance.InfoW(msg, fields...)
 }
 
 func Server(v ...interface{}) {
-	stackSync(fmt.Sprint(v...))
+	defaultInstance.Server(v...)
 }
 
 func ServerFormat(format string, v ...interface{}) {
-	stackSync(fmt.Sprintf(format, v...))
+	defaultInstance.ServerFormat(format, v...)
 }
 
 func Slow(v ...interface{}) {
-	slowSync(fmt.Sprintln(v...))
+	defaultInstance.Slow(v...)
 }
 
 func SlowFormat(format string, v ...interface{}) {
-	slowSync(fmt.Sprintf(fmt.Sprintf("%s\n", format), v...))
+	defaultInstance.SlowFormat(format, v...)
+}
+
+// SlowW logs msg at slow level, merging fields into the entry when JSON
+// encoding is enabled. Fields are dropped in plain text mode.
+func SlowW(msg string, fields ...LogField) {
+	defaultInstance.SlowW(msg, fields...)
 }
 
 func Stat(v ...interface{}) {
-	statSync(fmt.Sprintln(v...))
+	defaultInstance.Stat(v...)
 }
 
 func StatFormat(format string, v ...interface{}) {
-	statSync(fmt.Sprintf(fmt.Sprintf("%s\n", format), v...))
+	defaultInstance.StatFormat(format, v...)
+}
+
+// StatW logs msg at stat level, merging fields into the entry when JSON
+// encoding is enabled. Fields are dropped in plain text mode.
+func StatW(msg string, fields ...LogField) {
+	defaultInstance.StatW(msg, fields...)
+}
+
+func WithEncoding(encoding string) LogOption {
+	return func(opts *logOptions) {
+		opts.encoding = encoding
+	}
 }
 
 func WithCoolDownMillis(millis int) LogOption {
@@ -221,168 +241,89 @@ func WithGzip() LogOption {
 	}
 }
 
-func createOutput(path string) (io.WriteCloser, error) {
-	if len(path) == 0 {
-		return nil, ErrLogPathNotSet
-	}
-	return NewLogger(path, DefaultBackupRule(path, backupFileDelimiter, options.keepDays,
-		options.gzipEnabled), options.gzipEnabled)
-}
-
-func errorSync(msg string, callDepth int) {
-	if atomic.LoadUint32(&initialized) == 0 {
-		outputError(nil, msg, callDepth)
-	} else {
-		outputError(errorLog, msg, callDepth)
-	}
-}
-
-func getCaller(callDepth int) string {
-	var buf strings.Builder
-	_, file, line, ok := runtime.Caller(callDepth)
-	if ok {
-		short := file
-		for i := len(file) - 1; i > 0; i-- {
-			if file[i] == '/' {
-				short = file[i+1:]
-				break
-			}
-		}
-		buf.WriteString(short)
-		buf.WriteByte(':')
-		buf.WriteString(strconv.Itoa(line))
+// WithMaxSize caps a rotated log file at mb megabytes before it rolls over.
+// Only takes effect when WithRotationRule("size") is also set.
+func WithMaxSize(mb int) LogOption {
+	return func(opts *logOptions) {
+		opts.maxSize = int64(mb)
 	}
-
-	return buf.String()
 }
 
-func handleOptions(opts []LogOption) {
-	for _, opt := range opts {
-		opt(&options)
+// WithMaxLines caps a rotated log file at n lines before it rolls over.
+// Only takes effect when WithRotationRule("size") is also set.
+func WithMaxLines(n int) LogOption {
+	return func(opts *logOptions) {
+		opts.maxLines = int64(n)
 	}
 }
 
-func infoSync(msg string) {
-	if atomic.LoadUint32(&initialized) == 0 {
-		output(nil, msg)
-	} else {
-		output(infoLog, msg)
+// WithMaxBackups prunes backup files beyond the n most recent ones. Only
+// takes effect when WithRotationRule("size") is also set; the daily/hourly
+// rules prune by KeepDays instead.
+func WithMaxBackups(n int) LogOption {
+	return func(opts *logOptions) {
+		opts.maxBackups = n
 	}
 }
 
-func output(writer io.Writer, msg string) {
-	buf := AddTime(msg)
-	if writer != nil {
-		if _, err := writer.Write([]byte(buf)); err != nil {
-			log.Println(err)
-		}
-	} else {
-		log.Print(buf)
+// WithRotationRule selects the rotation strategy: "size", "daily" (the
+// default) or "hourly".
+func WithRotationRule(rule string) LogOption {
+	return func(opts *logOptions) {
+		opts.rotationRule = rule
 	}
 }
 
-func outputError(writer io.Writer, msg string, callDepth int) {
-	content := AddTimeAndCaller(msg, callDepth)
-	if writer != nil {
-		if _, err := writer.Write([]byte(content)); err != nil {
-			log.Println(err)
-		}
-	} else {
-		log.Print(content)
+// WithAlertHook registers hook to receive an AlertEntry whenever Alert,
+// Error, ErrorCaller, ErrorFormat, ErrorCallerFormat or ErrorW is called.
+// hook runs on a bounded background goroutine; entries are dropped (with a
+// counted warning) if it falls behind, and repeats of the same message are
+// throttled using the same cool-down as WithCoolDownMillis. NewWebhookAlert
+// builds a hook suitable for Slack/Feishu/DingTalk incoming webhooks.
+func WithAlertHook(hook AlertHook) LogOption {
+	return func(opts *logOptions) {
+		opts.alertHook = hook
 	}
 }
 
-func setupWithConsole() {
-	writeConsole = true
-	once.Do(func() {
-		infoLog = newLogWriter(log.New(os.Stdout, infoPrefix, flags))
-		errorLog = newLogWriter(log.New(os.Stderr, errorPrefix, flags))
-		slowLog = newLogWriter(log.New(os.Stderr, slowPrefix, flags))
-		statLog = infoLog
-		atomic.StoreUint32(&initialized, 1)
-	})
-}
-
-func setupWithFiles(c Config) error {
-	var opts []LogOption
-	var err error
-
-	if len(c.Path) == 0 {
-		return ErrLogPathNotSet
-	}
-
-	opts = append(opts, WithCoolDownMillis(c.StackCoolDownMillis))
-	if c.Compress {
-		opts = append(opts, WithGzip())
+func encodeEntry(level, caller, msg string, fields []LogField) string {
+	entry := make(map[string]interface{}, len(fields)+4)
+	entry["@timestamp"] = time.Now().Format(TimeFormat)
+	entry["level"] = level
+	if len(caller) > 0 {
+		entry["caller"] = caller
 	}
-	if c.KeepDays > 0 {
-		opts = append(opts, WithKeepDays(c.KeepDays))
+	entry["msg"] = msg
+	for _, field := range fields {
+		entry[field.Key] = field.Value
 	}
 
-	accessFile := path.Join(c.Path, accessFilename)
-	errorFile := path.Join(c.Path, errorFilename)
-	slowFile := path.Join(c.Path, slowFilename)
-	statFile := path.Join(c.Path, statFilename)
-
-	once.Do(func() {
-		handleOptions(opts)
-
-		if infoLog, err = createOutput(accessFile); err != nil {
-			return
-		}
-
-		if errorLog, err = createOutput(errorFile); err != nil {
-			return
-		}
-
-		if slowLog, err = createOutput(slowFile); err != nil {
-			return
-		}
-
-		if statLog, err = createOutput(statFile); err != nil {
-			return
-		}
-
-		stackLog = NewLessLogger(options.logStackCoolDownMills)
-		atomic.StoreUint32(&initialized, 1)
-	})
-
-	return err
-}
-
-func setupWithVolume(c Config) error {
-	if len(c.NameSpace) == 0 {
-		return ErrLogNameSpaceNotSet
+	b, err := json.Marshal(entry)
+	if err != nil {
+		log.Println(err)
+		return msg + "\n"
 	}
 
-	hostname := getHostname()
-	c.Path = path.Join(c.Path, c.NameSpace, hostname)
-
-	return setupWithFiles(c)
-}
-
-func slowSync(msg string) {
-	if atomic.LoadUint32(&initialized) == 0 {
-		output(nil, msg)
-	} else {
-		output(slowLog, msg)
-	}
+	return string(b) + "\n"
 }
 
-func stackSync(msg string) {
-	if atomic.LoadUint32(&initialized) == 0 {
-		output(nil, fmt.Sprintf("%s\n%s", msg, string(debug.Stack())))
-	} else {
-		stackLog.Errorf("%s\n%s", msg, string(debug.Stack()))
+func getCaller(callDepth int) string {
+	var buf strings.Builder
+	_, file, line, ok := runtime.Caller(callDepth)
+	if ok {
+		short := file
+		for i := len(file) - 1; i > 0; i-- {
+			if file[i] == '/' {
+				short = file[i+1:]
+				break
+			}
+		}
+		buf.WriteString(short)
+		buf.WriteByte(':')
+		buf.WriteString(strconv.Itoa(line))
 	}
-}
 
-func statSync(msg string) {
-	if atomic.LoadUint32(&initialized) == 0 {
-		output(nil, msg)
-	} else {
-		output(statLog, msg)
-	}
+	return buf.String()
 }
 
 type logWriter struct {
@@ -0,0 +1,41 @@
+package log4g
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEncodeEntry(t *testing.T) {
+	out := encodeEntry(levelError, "main.go:10", "boom\n", []LogField{{Key: "reqId", Value: "abc"}})
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal([]byte(out), &decoded); err != nil {
+		t.Fatalf("encodeEntry produced invalid JSON: %v\n%s", err, out)
+	}
+
+	if decoded["level"] != levelError {
+		t.Errorf("level = %v, want %v", decoded["level"], levelError)
+	}
+	if decoded["caller"] != "main.go:10" {
+		t.Errorf("caller = %v, want main.go:10", decoded["caller"])
+	}
+	if decoded["msg"] != "boom\n" {
+		t.Errorf("msg = %v, want %q", decoded["msg"], "boom\n")
+	}
+	if decoded["reqId"] != "abc" {
+		t.Errorf("reqId = %v, want abc", decoded["reqId"])
+	}
+}
+
+func TestEncodeEntryOmitsEmptyCaller(t *testing.T) {
+	out := encodeEntry(levelInfo, "", "hello\n", nil)
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal([]byte(out), &decoded); err != nil {
+		t.Fatalf("encodeEntry produced invalid JSON: %v\n%s", err, out)
+	}
+
+	if _, ok := decoded["caller"]; ok {
+		t.Errorf("expected no caller field when caller is empty, got %v", decoded["caller"])
+	}
+}
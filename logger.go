@@ -0,0 +1,126 @@
+package log4g
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const fileFlags = os.O_CREATE | os.O_APPEND | os.O_WRONLY
+
+// RotateLogger is an io.WriteCloser that appends to a single file, asking
+// rule before every write whether the current file has aged/grown past its
+// limit. On a positive answer it closes the file, rolls its contents into
+// the backup rule reports via BackupFileName (gzipping them first if
+// gzipEnabled), opens a fresh file at the original path, and prunes
+// whichever backups rule now considers OutdatedFiles.
+type RotateLogger struct {
+	lock        sync.Mutex
+	filename    string
+	rule        BackupRule
+	gzipEnabled bool
+	fp          *os.File
+}
+
+// NewLogger opens (creating it and its parent directory if necessary)
+// filename for appending and rotates it according to rule.
+func NewLogger(filename string, rule BackupRule, gzipEnabled bool) (*RotateLogger, error) {
+	l := &RotateLogger{
+		filename:    filename,
+		rule:        rule,
+		gzipEnabled: gzipEnabled,
+	}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+func (l *RotateLogger) open() error {
+	if err := os.MkdirAll(filepath.Dir(l.filename), 0o755); err != nil {
+		return err
+	}
+
+	fp, err := os.OpenFile(l.filename, fileFlags, 0o644)
+	if err != nil {
+		return err
+	}
+
+	l.fp = fp
+
+	return nil
+}
+
+func (l *RotateLogger) Write(data []byte) (int, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.rule.ShouldRotate(int64(len(data))) {
+		if err := l.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	return l.fp.Write(data)
+}
+
+func (l *RotateLogger) rotate() error {
+	if err := l.fp.Close(); err != nil {
+		return err
+	}
+
+	backupName := l.rule.BackupFileName()
+	if l.gzipEnabled {
+		if err := gzipFile(l.filename, backupName); err != nil {
+			return err
+		}
+		if err := os.Remove(l.filename); err != nil {
+			return err
+		}
+	} else if err := os.Rename(l.filename, backupName); err != nil {
+		return err
+	}
+
+	if err := l.open(); err != nil {
+		return err
+	}
+
+	l.rule.MarkRotated()
+	for _, outdated := range l.rule.OutdatedFiles() {
+		os.Remove(outdated)
+	}
+
+	return nil
+}
+
+func (l *RotateLogger) Close() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return l.fp.Close()
+}
+
+func gzipFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	gw := gzip.NewWriter(out)
+	if _, err := io.Copy(gw, in); err != nil {
+		gw.Close()
+		return err
+	}
+
+	return gw.Close()
+}
@@ -0,0 +1,176 @@
+package log4g
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	rotationRuleDaily  = "daily"
+	rotationRuleHourly = "hourly"
+	rotationRuleSize   = "size"
+
+	dailyBackupTimeFormat  = "2006-01-02"
+	hourlyBackupTimeFormat = "2006-01-02-15"
+	bytesPerMB             = 1024 * 1024
+)
+
+// BackupRule decides when a rotating log file should roll over to a new
+// backup file and which of its older backups have aged out. The RotateLogger
+// returned by NewLogger calls ShouldRotate before every write and
+// MarkRotated right after starting a new backup file.
+type BackupRule interface {
+	MarkRotated()
+	OutdatedFiles() []string
+	ShouldRotate(size int64) bool
+	BackupFileName() string
+}
+
+// SizeBackupRule rotates a log file once it has grown past MaxSize megabytes
+// or MaxLines lines, whichever limit is configured and reached first,
+// keeping at most MaxBackups archives around. A zero limit disables that
+// trigger.
+type SizeBackupRule struct {
+	filename    string
+	delimiter   string
+	maxBytes    int64
+	maxLines    int64
+	maxBackups  int
+	gzipEnabled bool
+
+	bytesWritten int64
+	linesWritten int64
+	index        int
+}
+
+// NewSizeBackupRule returns a SizeBackupRule for filename. maxSize is in
+// megabytes; a zero maxSize or maxLines disables that trigger.
+func NewSizeBackupRule(filename, delimiter string, maxSize, maxLines int64, maxBackups int,
+	gzipEnabled bool) *SizeBackupRule {
+	return &SizeBackupRule{
+		filename:    filename,
+		delimiter:   delimiter,
+		maxBytes:    maxSize * bytesPerMB,
+		maxLines:    maxLines,
+		maxBackups:  maxBackups,
+		gzipEnabled: gzipEnabled,
+	}
+}
+
+func (r *SizeBackupRule) MarkRotated() {
+	atomic.StoreInt64(&r.bytesWritten, 0)
+	atomic.StoreInt64(&r.linesWritten, 0)
+	r.index++
+}
+
+func (r *SizeBackupRule) OutdatedFiles() []string {
+	if r.maxBackups <= 0 || r.index <= r.maxBackups {
+		return nil
+	}
+
+	var files []string
+	for i := 1; i <= r.index-r.maxBackups; i++ {
+		files = append(files, r.backupName(i))
+	}
+
+	return files
+}
+
+func (r *SizeBackupRule) ShouldRotate(size int64) bool {
+	bytesWritten := atomic.AddInt64(&r.bytesWritten, size)
+	linesWritten := atomic.AddInt64(&r.linesWritten, 1)
+
+	return r.maxBytes > 0 && bytesWritten >= r.maxBytes || r.maxLines > 0 && linesWritten >= r.maxLines
+}
+
+func (r *SizeBackupRule) BackupFileName() string {
+	return r.backupName(r.index + 1)
+}
+
+func (r *SizeBackupRule) backupName(index int) string {
+	name := fmt.Sprintf("%s%s%d", r.filename, r.delimiter, index)
+	if r.gzipEnabled {
+		name += ".gz"
+	}
+
+	return name
+}
+
+// timeBackupRule rotates a log file once the wall clock crosses into a new
+// period, appending time.Now().Format(format) to the backup filename.
+// DefaultBackupRule covers the calendar-day case; NewHourlyBackupRule reuses
+// this for the hourly case.
+type timeBackupRule struct {
+	filename    string
+	delimiter   string
+	format      string
+	keepDays    int
+	gzipEnabled bool
+	rotatedTime string
+}
+
+func newTimeBackupRule(filename, delimiter, format string, keepDays int, gzipEnabled bool) *timeBackupRule {
+	return &timeBackupRule{
+		filename:    filename,
+		delimiter:   delimiter,
+		format:      format,
+		keepDays:    keepDays,
+		gzipEnabled: gzipEnabled,
+		rotatedTime: time.Now().Format(format),
+	}
+}
+
+func (r *timeBackupRule) MarkRotated() {
+	r.rotatedTime = time.Now().Format(r.format)
+}
+
+func (r *timeBackupRule) BackupFileName() string {
+	name := fmt.Sprintf("%s%s%s", r.filename, r.delimiter, time.Now().Format(r.format))
+	if r.gzipEnabled {
+		name += ".gz"
+	}
+
+	return name
+}
+
+func (r *timeBackupRule) ShouldRotate(_ int64) bool {
+	return len(r.rotatedTime) > 0 && r.rotatedTime != time.Now().Format(r.format)
+}
+
+func (r *timeBackupRule) OutdatedFiles() []string {
+	if r.keepDays <= 0 {
+		return nil
+	}
+
+	boundary := time.Now().Add(-time.Duration(r.keepDays) * 24 * time.Hour).Format(r.format)
+	matches, err := filepath.Glob(r.filename + r.delimiter + "*")
+	if err != nil {
+		return nil
+	}
+
+	var outdated []string
+	for _, name := range matches {
+		suffix := strings.TrimSuffix(strings.TrimPrefix(name, r.filename+r.delimiter), ".gz")
+		if suffix < boundary {
+			outdated = append(outdated, name)
+		}
+	}
+
+	return outdated
+}
+
+// DefaultBackupRule rotates a log file once the calendar day changes,
+// appending the "2006-01-02" suffix and keeping the most recent keepDays
+// days of backups.
+func DefaultBackupRule(filename, delimiter string, keepDays int, gzipEnabled bool) BackupRule {
+	return newTimeBackupRule(filename, delimiter, dailyBackupTimeFormat, keepDays, gzipEnabled)
+}
+
+// NewHourlyBackupRule mirrors DefaultBackupRule but rotates on the hour,
+// using the "2006-01-02-15" suffix instead of a calendar day.
+func NewHourlyBackupRule(filename, delimiter string, keepDays int, gzipEnabled bool) BackupRule {
+	return newTimeBackupRule(filename, delimiter, hourlyBackupTimeFormat, keepDays, gzipEnabled)
+}
@@ -0,0 +1,79 @@
+package log4g
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSizeBackupRuleShouldRotateByBytes(t *testing.T) {
+	r := NewSizeBackupRule("/tmp/access.log", "-", 1, 0, 3, false)
+
+	if r.ShouldRotate(bytesPerMB / 2) {
+		t.Fatal("should not rotate before reaching maxBytes")
+	}
+	if !r.ShouldRotate(bytesPerMB) {
+		t.Fatal("expected rotation once bytesWritten crosses maxBytes")
+	}
+}
+
+func TestSizeBackupRuleShouldRotateByLines(t *testing.T) {
+	r := NewSizeBackupRule("/tmp/access.log", "-", 0, 2, 3, false)
+
+	if r.ShouldRotate(1) {
+		t.Fatal("should not rotate before reaching maxLines")
+	}
+	if !r.ShouldRotate(1) {
+		t.Fatal("expected rotation once linesWritten crosses maxLines")
+	}
+}
+
+func TestSizeBackupRuleOutdatedFiles(t *testing.T) {
+	r := NewSizeBackupRule("/tmp/access.log", "-", 0, 1, 2, false)
+
+	if files := r.OutdatedFiles(); files != nil {
+		t.Fatalf("expected no outdated files before any rotation, got %v", files)
+	}
+
+	for i := 0; i < 3; i++ {
+		r.MarkRotated()
+	}
+
+	files := r.OutdatedFiles()
+	if len(files) != 1 {
+		t.Fatalf("expected 1 outdated file after 3 rotations with maxBackups=2, got %v", files)
+	}
+	if want := "/tmp/access.log-1"; files[0] != want {
+		t.Errorf("expected outdated file %q, got %q", want, files[0])
+	}
+}
+
+func TestSizeBackupRuleBackupFileNameGzip(t *testing.T) {
+	r := NewSizeBackupRule("/tmp/access.log", "-", 1, 0, 0, true)
+
+	if want := "/tmp/access.log-1.gz"; r.BackupFileName() != want {
+		t.Errorf("expected %q, got %q", want, r.BackupFileName())
+	}
+}
+
+func TestDefaultBackupRuleOutdatedFiles(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "access.log")
+
+	rule := DefaultBackupRule(filename, "-", 1, false).(*timeBackupRule)
+	rule.rotatedTime = "2000-01-01"
+
+	old := filename + "-2000-01-01"
+	if err := os.WriteFile(old, nil, 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	if !rule.ShouldRotate(0) {
+		t.Fatal("expected ShouldRotate to report true once the calendar day changed")
+	}
+
+	files := rule.OutdatedFiles()
+	if len(files) != 1 || files[0] != old {
+		t.Fatalf("expected %v to be outdated, got %v", []string{old}, files)
+	}
+}
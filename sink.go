@@ -0,0 +1,259 @@
+package log4g
+
+import (
+	"fmt"
+	"io"
+	"log/syslog"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	// LevelInfo and friends are the integer levels passed to Sink.Write,
+	// mirroring the levelInfo/levelError/... string labels used for JSON.
+	LevelInfo = iota
+	LevelError
+	LevelSlow
+	LevelStat
+
+	sinkDialTimeout = 3 * time.Second
+)
+
+type (
+	// Sink is a log destination other than the local filesystem, such as
+	// syslog, a TCP collector, or a message queue. SetUp wires one up per
+	// entry in Config.Sinks and fans every write out to it alongside the
+	// regular file/console output.
+	Sink interface {
+		Write(level int, entry []byte) error
+		Close() error
+	}
+
+	// SinkConfig is one entry of Config.Sinks.
+	SinkConfig struct {
+		// Kind selects the sink implementation: "syslog" or "tcp".
+		Kind string `json:",options=syslog|tcp"`
+		// Addr is the network address the sink connects to, e.g. "127.0.0.1:514".
+		Addr string `json:",optional"`
+		// Tag is the syslog tag, only used when Kind is "syslog".
+		Tag string `json:",optional"`
+		// ReconnectOnMsg makes a TCP sink redial before every write instead of
+		// keeping the connection open, trading throughput for resilience.
+		ReconnectOnMsg bool `json:",optional"`
+	}
+)
+
+// ioSink adapts the existing io.WriteCloser file/console writers to Sink so
+// infoLog/errorLog/slowLog/statLog can be driven through a single interface
+// regardless of whether Config.Sinks is set.
+type ioSink struct {
+	writer io.WriteCloser
+}
+
+func newIOSink(writer io.WriteCloser) ioSink {
+	return ioSink{writer: writer}
+}
+
+func (s ioSink) Write(_ int, entry []byte) error {
+	_, err := s.writer.Write(entry)
+	return err
+}
+
+func (s ioSink) Close() error {
+	return s.writer.Close()
+}
+
+// SyslogSink ships log entries to a syslog daemon.
+type SyslogSink struct {
+	closeOnce sync.Once
+	closeErr  error
+	writer    *syslog.Writer
+}
+
+// NewSyslogSink dials the syslog daemon at addr over udp, tagging every
+// message with tag. An empty addr dials the local syslog daemon.
+func NewSyslogSink(addr, tag string) (*SyslogSink, error) {
+	network := "udp"
+	if len(addr) == 0 {
+		network = ""
+	}
+
+	writer, err := syslog.Dial(network, addr, syslog.LOG_INFO, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SyslogSink{writer: writer}, nil
+}
+
+func (s *SyslogSink) Write(level int, entry []byte) error {
+	if level == LevelError {
+		return s.writer.Err(string(entry))
+	}
+
+	return s.writer.Info(string(entry))
+}
+
+// Close is idempotent: Config.Sinks entries are shared across the
+// info/error/slow/stat MultiSinks built by createOutput, so Instance.Close
+// ends up calling this once per log level.
+func (s *SyslogSink) Close() error {
+	s.closeOnce.Do(func() {
+		s.closeErr = s.writer.Close()
+	})
+
+	return s.closeErr
+}
+
+// TCPSink streams log entries to a TCP collector, modeled on Beego's
+// ConnWriter. By default the connection is kept open and redialed lazily on
+// write failure; set reconnectOnMsg to force a fresh connection per write.
+type TCPSink struct {
+	lock           sync.Mutex
+	addr           string
+	conn           net.Conn
+	reconnectOnMsg bool
+}
+
+// NewTCPSink returns a TCPSink that writes to addr, dialing lazily on first use.
+func NewTCPSink(addr string, reconnectOnMsg bool) *TCPSink {
+	return &TCPSink{
+		addr:           addr,
+		reconnectOnMsg: reconnectOnMsg,
+	}
+}
+
+func (s *TCPSink) Write(_ int, entry []byte) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.conn == nil || s.reconnectOnMsg {
+		if err := s.redial(); err != nil {
+			return err
+		}
+	}
+
+	if _, err := s.conn.Write(entry); err != nil {
+		s.conn.Close()
+		s.conn = nil
+		return err
+	}
+
+	return nil
+}
+
+func (s *TCPSink) redial() error {
+	conn, err := net.DialTimeout("tcp", s.addr, sinkDialTimeout)
+	if err != nil {
+		return err
+	}
+
+	if s.conn != nil {
+		s.conn.Close()
+	}
+	s.conn = conn
+
+	return nil
+}
+
+// Close is idempotent: Config.Sinks entries are shared across the
+// info/error/slow/stat MultiSinks built by createOutput, so Instance.Close
+// ends up calling this once per log level.
+func (s *TCPSink) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+
+	err := s.conn.Close()
+	s.conn = nil
+
+	return err
+}
+
+// MultiSink fans a single write out to several sinks, returning the first
+// error encountered so one failing destination doesn't block the others.
+type MultiSink struct {
+	sinks []Sink
+}
+
+// NewMultiSink returns a Sink that writes to every one of sinks.
+func NewMultiSink(sinks ...Sink) *MultiSink {
+	return &MultiSink{sinks: sinks}
+}
+
+func (m *MultiSink) Write(level int, entry []byte) error {
+	var firstErr error
+	for _, sink := range m.sinks {
+		if err := sink.Write(level, entry); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+func (m *MultiSink) Close() error {
+	var firstErr error
+	for _, sink := range m.sinks {
+		if err := sink.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+func newConfiguredSink(c SinkConfig) (Sink, error) {
+	switch c.Kind {
+	case "syslog":
+		return NewSyslogSink(c.Addr, c.Tag)
+	case "tcp":
+		return NewTCPSink(c.Addr, c.ReconnectOnMsg), nil
+	default:
+		return nil, fmt.Errorf("log4g: unknown sink kind %q", c.Kind)
+	}
+}
+
+func buildSinks(configs []SinkConfig) ([]Sink, error) {
+	if len(configs) == 0 {
+		return nil, nil
+	}
+
+	sinks := make([]Sink, 0, len(configs))
+	for _, conf := range configs {
+		sink, err := newConfiguredSink(conf)
+		if err != nil {
+			closeSinks(sinks)
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+
+	return sinks, nil
+}
+
+// closeSinks closes every sink in sinks, ignoring errors: it's used to tear
+// down sinks that were already built when a later one in the same batch
+// fails, so a partial buildSinks failure doesn't leak their connections.
+func closeSinks(sinks []Sink) {
+	for _, sink := range sinks {
+		sink.Close()
+	}
+}
+
+func levelCode(level string) int {
+	switch level {
+	case levelError:
+		return LevelError
+	case levelSlow:
+		return LevelSlow
+	case levelStat:
+		return LevelStat
+	default:
+		return LevelInfo
+	}
+}
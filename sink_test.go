@@ -0,0 +1,122 @@
+package log4g
+
+import (
+	"net"
+	"os"
+	"sync/atomic"
+	"testing"
+)
+
+// fakeConn is a minimal net.Conn that only tracks how many times Close was
+// called, erroring on any call past the first like a real closed socket would.
+type fakeConn struct {
+	net.Conn
+	closes int
+}
+
+func (c *fakeConn) Close() error {
+	c.closes++
+	if c.closes > 1 {
+		return net.ErrClosed
+	}
+
+	return nil
+}
+
+// TestTCPSinkCloseIdempotent guards against createOutput wrapping the same
+// Config.Sinks entry into multiple MultiSinks (one per log level), each of
+// which calls Close on shutdown: a non-idempotent Close would surface a
+// spurious "use of closed network connection" error from the second call.
+func TestTCPSinkCloseIdempotent(t *testing.T) {
+	conn := &fakeConn{}
+	s := &TCPSink{conn: conn}
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("first Close: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("second Close: %v", err)
+	}
+	if conn.closes != 1 {
+		t.Fatalf("expected the underlying conn to be closed once, got %d", conn.closes)
+	}
+}
+
+// stubSink is a Sink double used to confirm console mode fans out to
+// Config.Sinks instead of silently dropping them.
+type stubSink struct {
+	closes int
+}
+
+func (s *stubSink) Write(_ int, _ []byte) error { return nil }
+
+func (s *stubSink) Close() error {
+	s.closes++
+	return nil
+}
+
+// TestBuildSinksReturnsNoSinksOnFailure drives buildSinks down its cleanup
+// path: a later SinkConfig entry fails after an earlier one opened a
+// connection. It dials a real SyslogSink over udp, which succeeds without a
+// listener since udp dial never verifies reachability, then forces the next
+// entry to fail on an unknown kind, and checks that an error and no sinks
+// come back. Whether the syslog sink got closed is not observed here;
+// TestCloseSinksClosesEvery covers closeSinks itself.
+func TestBuildSinksReturnsNoSinksOnFailure(t *testing.T) {
+	sinks, err := buildSinks([]SinkConfig{
+		{Kind: "syslog", Addr: "127.0.0.1:1", Tag: "test"},
+		{Kind: "bogus"},
+	})
+	if err == nil {
+		t.Fatal("expected an error from the unknown sink kind")
+	}
+	if sinks != nil {
+		t.Fatalf("expected no sinks to be returned on failure, got %v", sinks)
+	}
+}
+
+// TestCloseSinksClosesEvery guards against buildSinks' cleanup path,
+// closeSinks, skipping any sink in the slice.
+func TestCloseSinksClosesEvery(t *testing.T) {
+	a, b := &stubSink{}, &stubSink{}
+	closeSinks([]Sink{a, b})
+
+	if a.closes != 1 || b.closes != 1 {
+		t.Fatalf("expected both sinks closed once, got %d and %d", a.closes, b.closes)
+	}
+}
+
+func TestConsoleSinkFansOutToExternalSinks(t *testing.T) {
+	inst := new(Instance)
+
+	got := inst.consoleSink(os.Stdout, infoPrefix, []Sink{&stubSink{}})
+	multi, ok := got.(*MultiSink)
+	if !ok {
+		t.Fatalf("expected a *MultiSink wrapping the console writer and external sinks, got %T", got)
+	}
+	if len(multi.sinks) != 2 {
+		t.Fatalf("expected 2 fanned-out sinks, got %d", len(multi.sinks))
+	}
+}
+
+// TestInstanceCloseClosesConsoleSinks guards against Close returning early in
+// console mode before closing the external Sink(s) wired in via
+// Config.Sinks/consoleSink, which would otherwise leak a live TCP/syslog
+// connection on every restart.
+func TestInstanceCloseClosesConsoleSinks(t *testing.T) {
+	inst := new(Instance)
+	inst.writeConsole = true
+	external := &stubSink{}
+	inst.infoLog = inst.consoleSink(os.Stdout, infoPrefix, []Sink{external})
+	inst.errorLog = inst.consoleSink(os.Stderr, errorPrefix, []Sink{external})
+	inst.slowLog = inst.consoleSink(os.Stderr, slowPrefix, []Sink{external})
+	inst.statLog = inst.infoLog
+	atomic.StoreUint32(&inst.initialized, 1)
+
+	if err := inst.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if external.closes == 0 {
+		t.Fatal("expected the external sink wired via consoleSink to be closed")
+	}
+}
@@ -0,0 +1,69 @@
+package log4g
+
+import "testing"
+
+func TestParseVModule(t *testing.T) {
+	patterns, err := parseVModule("cache=2, handler/*=3,,")
+	if err != nil {
+		t.Fatalf("parseVModule: %v", err)
+	}
+	if len(patterns) != 2 {
+		t.Fatalf("expected 2 patterns, got %d: %+v", len(patterns), patterns)
+	}
+	if patterns[0].pattern != "cache" || patterns[0].level != 2 {
+		t.Errorf("unexpected first pattern: %+v", patterns[0])
+	}
+	if patterns[1].pattern != "handler/*" || patterns[1].level != 3 {
+		t.Errorf("unexpected second pattern: %+v", patterns[1])
+	}
+}
+
+func TestParseVModuleInvalid(t *testing.T) {
+	for _, spec := range []string{"cache", "cache=notanumber"} {
+		if _, err := parseVModule(spec); err == nil {
+			t.Errorf("parseVModule(%q): expected an error, got nil", spec)
+		}
+	}
+}
+
+// TestNewVModuleIsProcessWide documents that Config.VModule set on one
+// Instance overrides whatever an earlier Instance configured: New doesn't
+// scope VModule to the Instance being built, so the last call wins for
+// every Instance in the process. See the Instance doc comment.
+func TestNewVModuleIsProcessWide(t *testing.T) {
+	defer func() {
+		_ = SetVModule("")
+	}()
+
+	if _, err := New(Config{LogMode: consoleMode, VModule: "aaa=5"}); err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := New(Config{LogMode: consoleMode, VModule: "bbb=5"}); err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	patterns, _ := vmodulePatterns.Load().([]modulePattern)
+	if len(patterns) != 1 || patterns[0].pattern != "bbb" {
+		t.Fatalf("expected only the second Instance's VModule to survive, got %+v", patterns)
+	}
+}
+
+func TestModuleMatches(t *testing.T) {
+	cases := []struct {
+		pattern string
+		file    string
+		want    bool
+	}{
+		{"cache", "/src/app/cache.go", true},
+		{"cache", "/src/app/cache_test.go", false},
+		{"ca*", "/src/app/cache.go", true},
+		{"handler/*", "/src/app/handler/user.go", true},
+		{"handler/*", "/src/app/other/user.go", false},
+	}
+
+	for _, c := range cases {
+		if got := moduleMatches(c.pattern, c.file); got != c.want {
+			t.Errorf("moduleMatches(%q, %q) = %v, want %v", c.pattern, c.file, got, c.want)
+		}
+	}
+}
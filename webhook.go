@@ -0,0 +1,60 @@
+package log4g
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// NewWebhookAlert returns an AlertHook that POSTs entry to url as a
+// {"text": "..."} envelope, the common denominator Slack/Feishu/DingTalk
+// incoming-webhook endpoints all expect. The request is aborted if it
+// takes longer than timeout.
+func NewWebhookAlert(url string, timeout time.Duration) AlertHook {
+	client := &http.Client{Timeout: timeout}
+
+	return func(entry AlertEntry) error {
+		body, err := json.Marshal(webhookPayload{Text: formatWebhookText(entry)})
+		if err != nil {
+			return err
+		}
+
+		resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode >= http.StatusBadRequest {
+			return fmt.Errorf("log4g: webhook alert got status %d", resp.StatusCode)
+		}
+
+		return nil
+	}
+}
+
+// webhookPayload is the envelope NewWebhookAlert posts: Slack, Feishu and
+// DingTalk incoming webhooks all render a top-level "text" field out of the
+// box, unlike AlertEntry's own JSON shape.
+type webhookPayload struct {
+	Text string `json:"text"`
+}
+
+// formatWebhookText folds entry into one plain-text string (the message,
+// then the caller in parentheses, then each field as key=value; a trailing
+// newline in the message is kept), since none of
+// Slack/Feishu/DingTalk's default incoming-webhook payload understands
+// AlertEntry's structured timestamp/caller/fields.
+func formatWebhookText(entry AlertEntry) string {
+	text := entry.Message
+	if entry.Caller != "" {
+		text = fmt.Sprintf("%s (%s)", text, entry.Caller)
+	}
+	for _, f := range entry.Fields {
+		text = fmt.Sprintf("%s %s=%v", text, f.Key, f.Value)
+	}
+
+	return text
+}
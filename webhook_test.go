@@ -0,0 +1,31 @@
+package log4g
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestNewWebhookAlertPostsTextEnvelope guards against NewWebhookAlert posting
+// AlertEntry's own JSON shape, which Slack/Feishu/DingTalk incoming webhooks
+// don't understand: they all expect a top-level "text" field.
+func TestNewWebhookAlertPostsTextEnvelope(t *testing.T) {
+	var got webhookPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode request body: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	hook := NewWebhookAlert(srv.URL, time.Second)
+	if err := hook(AlertEntry{Caller: "main.go:10", Message: "boom"}); err != nil {
+		t.Fatalf("hook: %v", err)
+	}
+
+	if got.Text == "" {
+		t.Fatal("expected a non-empty text field")
+	}
+}